@@ -0,0 +1,770 @@
+package download
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc"
+	"github.com/cli/cli/v2/internal/ghrepo"
+	"github.com/cli/cli/v2/internal/prompter"
+	"github.com/cli/cli/v2/pkg/cmd/run/shared"
+	"github.com/cli/cli/v2/pkg/cmdutil"
+	"github.com/cli/cli/v2/pkg/iostreams"
+	"github.com/spf13/cobra"
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/azureblob"
+	_ "gocloud.dev/blob/gcsblob"
+	_ "gocloud.dev/blob/s3blob"
+	"golang.org/x/sync/errgroup"
+)
+
+type DownloadOptions struct {
+	IO       *iostreams.IOStreams
+	Platform Platform
+	Prompter prompter.Prompter
+
+	// Context is the cancellation context for the run. It defaults to
+	// context.Background() when left unset, which is the case for most
+	// existing tests that don't care about cancellation.
+	Context context.Context
+
+	RunID          string
+	DestinationDir string
+	Names          []string
+	FilePatterns   []string
+	DoPrompt       bool
+	SkipChecksum   bool
+	Concurrency    int
+	MaxRetries     int
+}
+
+// defaultConcurrency is how many artifacts are downloaded at once when
+// --concurrency isn't specified.
+const defaultConcurrency = 5
+
+// defaultMaxRetries is how many times a single artifact download is retried
+// after a transient failure when --max-retries isn't specified.
+const defaultMaxRetries = 3
+
+// Platform is the source that artifacts are listed from and downloaded
+// through. Every method takes a context so that an interactive Ctrl+C (or
+// any other caller-driven cancellation) can abort in-flight network activity
+// immediately instead of waiting for the current request to finish.
+type Platform interface {
+	List(ctx context.Context, runID string) ([]shared.Artifact, error)
+	Download(ctx context.Context, url string, dir string) error
+}
+
+// ChecksumPlatform is implemented by platforms that can verify an artifact's
+// integrity against its expected SHA-256 digest while downloading it. It's
+// satisfied by the real API-backed platform; test doubles that don't care
+// about checksums can leave it unimplemented and fall back to Download.
+type ChecksumPlatform interface {
+	DownloadWithChecksum(ctx context.Context, url string, dir string, expectedSHA256 string) error
+}
+
+func NewCmdDownload(f *cmdutil.Factory, runF func(*DownloadOptions) error) *cobra.Command {
+	opts := &DownloadOptions{
+		IO:          f.IOStreams,
+		Concurrency: defaultConcurrency,
+		MaxRetries:  defaultMaxRetries,
+	}
+	var verify, noVerify bool
+
+	cmd := &cobra.Command{
+		Use:   "download [<run-id>]",
+		Short: "Download artifacts generated by a workflow run",
+		Long: heredoc.Doc(`
+			Download artifacts generated by a GitHub Actions workflow run.
+
+			The contents of each artifact will be extracted under separate directories based on
+			the artifact name. If only one artifact is available, the configured destination
+			directory will be used for extracting the files.
+
+			The destination directory can also be a cloud storage URL (s3://, gs://, or
+			azblob://), in which case extracted files are uploaded to that bucket instead of
+			written to the local filesystem.
+		`),
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.RunID = args[0]
+			}
+
+			if opts.RunID == "" && len(opts.Names) == 0 && len(opts.FilePatterns) == 0 {
+				if !opts.IO.CanPrompt() {
+					return cmdutil.FlagErrorf("run ID required when not running interactively")
+				}
+				opts.DoPrompt = true
+			}
+
+			httpClient, err := f.HttpClient()
+			if err != nil {
+				return err
+			}
+			baseRepo, err := f.BaseRepo()
+			if err != nil {
+				return err
+			}
+			opts.Platform = &apiPlatform{
+				client: httpClient,
+				repo:   baseRepo,
+			}
+			opts.Prompter = f.Prompter
+			opts.Context = cmd.Context()
+
+			if cmd.Flags().Changed("no-verify") {
+				opts.SkipChecksum = noVerify
+			} else {
+				opts.SkipChecksum = !verify
+			}
+
+			if runF != nil {
+				return runF(opts)
+			}
+			return runDownload(opts)
+		},
+	}
+
+	cmd.Flags().StringArrayVarP(&opts.Names, "name", "n", nil, "Filter artifacts by name")
+	cmd.Flags().StringArrayVarP(&opts.FilePatterns, "pattern", "p", nil, "Filter artifacts by a glob pattern")
+	cmd.Flags().StringVarP(&opts.DestinationDir, "dir", "D", ".", "The directory to download artifacts into")
+	cmd.Flags().BoolVar(&verify, "verify", true, "Verify artifact checksums before extracting")
+	cmd.Flags().BoolVar(&noVerify, "no-verify", false, "Skip checksum verification of downloaded artifacts")
+	cmd.Flags().IntVar(&opts.Concurrency, "concurrency", defaultConcurrency, "Number of artifacts to download at once")
+	cmd.Flags().IntVar(&opts.MaxRetries, "max-retries", defaultMaxRetries, "Number of times to retry a download after a transient failure")
+
+	return cmd
+}
+
+func runDownload(opts *DownloadOptions) error {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	artifacts, err := opts.Platform.List(ctx, opts.RunID)
+	if err != nil {
+		return fmt.Errorf("error fetching artifacts: %w", err)
+	}
+
+	artifacts = filterExpired(artifacts)
+	if len(artifacts) == 0 {
+		return errors.New("no valid artifacts found to download")
+	}
+
+	artifacts, err = filterArtifacts(artifacts, opts.Names, opts.FilePatterns, opts.DoPrompt, opts.Prompter)
+	if err != nil {
+		return err
+	}
+	if len(artifacts) == 0 {
+		return errors.New("no artifact matches any of the names or patterns provided")
+	}
+
+	// A selected name can still match more than one artifact if the workflow
+	// uploaded duplicates; only the first is kept so two artifacts never
+	// race to extract into the same destination.
+	artifacts = dedupeByName(artifacts)
+
+	destDir := opts.DestinationDir
+	if isCloudURL(destDir) {
+		dest, closeDest, err := openDestination(ctx, destDir)
+		if err != nil {
+			return err
+		}
+		defer closeDest()
+
+		stagingDir, err := os.MkdirTemp("", "gh-download-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(stagingDir)
+
+		if err := downloadAll(ctx, opts, artifacts, stagingDir); err != nil {
+			return err
+		}
+
+		return uploadToDestination(ctx, stagingDir, dest)
+	}
+
+	return downloadAll(ctx, opts, artifacts, destDir)
+}
+
+// downloadAll fans the given artifacts out across a bounded worker pool so
+// that up to opts.Concurrency downloads run at once. The first failure
+// cancels the group's context, which stops the other in-flight workers
+// instead of letting them run to completion.
+func downloadAll(ctx context.Context, opts *DownloadOptions, artifacts []shared.Artifact, destDir string) error {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+
+	for _, a := range artifacts {
+		a := a
+		finalDir := destDir
+		if len(artifacts) > 1 {
+			finalDir = filepath.Join(destDir, a.Name)
+		}
+
+		g.Go(func() error {
+			// A worker can still be started for an artifact queued behind
+			// one that just failed: SetLimit only bounds how many run
+			// concurrently, it doesn't stop the next one from being
+			// launched once a slot frees up. Bail out before touching the
+			// network if the group has already been cancelled.
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			// Extract into a staging directory of our own rather than
+			// straight into finalDir: for a single-artifact run finalDir is
+			// the caller's --dir itself, and a cancelled or failed download
+			// must only ever discard what this goroutine created, never
+			// the caller-supplied destination.
+			stagingDir, err := os.MkdirTemp("", "gh-download-*")
+			if err != nil {
+				return fmt.Errorf("error downloading %s: %w", a.Name, err)
+			}
+			defer os.RemoveAll(stagingDir)
+
+			if err := downloadWithRetry(ctx, opts, a, stagingDir); err != nil {
+				return fmt.Errorf("error downloading %s: %w", a.Name, err)
+			}
+
+			if err := moveTree(stagingDir, finalDir); err != nil {
+				return fmt.Errorf("error downloading %s: %w", a.Name, err)
+			}
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// Destination is where extracted artifact files are ultimately written: the
+// local filesystem, or a cloud bucket reached through a gocloud.dev/blob URL
+// (s3://, gs://, azblob://).
+type Destination interface {
+	WriteFile(ctx context.Context, name string, r io.Reader) error
+}
+
+// isCloudURL reports whether dest names a gocloud.dev/blob bucket (e.g.
+// s3://bucket, gs://bucket, azblob://container) rather than a filesystem
+// path.
+func isCloudURL(dest string) bool {
+	return strings.Contains(dest, "://")
+}
+
+func openDestination(ctx context.Context, dest string) (Destination, func(), error) {
+	bucket, err := blob.OpenBucket(ctx, dest)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error opening %s: %w", dest, err)
+	}
+	return &blobDestination{bucket: bucket}, func() { _ = bucket.Close() }, nil
+}
+
+type blobDestination struct {
+	bucket *blob.Bucket
+}
+
+func (d *blobDestination) WriteFile(ctx context.Context, name string, r io.Reader) error {
+	w, err := d.bucket.NewWriter(ctx, filepath.ToSlash(name), nil)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// uploadToDestination copies every file under stagingDir to dest, keyed by
+// its path relative to stagingDir, so a local `<artifact-name>/<file>` layout
+// becomes the equivalent `<artifact-name>/<file>` object key in the bucket.
+func uploadToDestination(ctx context.Context, stagingDir string, dest Destination) error {
+	return filepath.WalkDir(stagingDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(stagingDir, path)
+		if err != nil {
+			return err
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		return dest.WriteFile(ctx, rel, f)
+	})
+}
+
+// downloadWithRetry calls downloadArtifact, retrying a failed attempt with
+// exponential backoff and jitter up to opts.MaxRetries times. A cancelled
+// context is never retried, and neither is a permanentError: one more attempt
+// at a 4xx response or a checksum mismatch would just reproduce the same
+// failure. Once it gives up for good, it gives the platform a chance to
+// discard any on-disk state it kept around to resume a later attempt.
+func downloadWithRetry(ctx context.Context, opts *DownloadOptions, a shared.Artifact, dir string) (err error) {
+	defer func() {
+		if err != nil {
+			cleanupFailedDownload(opts.Platform, a.DownloadURL)
+		}
+	}()
+
+	maxRetries := opts.MaxRetries
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			if sleepErr := sleepWithBackoff(ctx, attempt); sleepErr != nil {
+				err = sleepErr
+				return err
+			}
+		}
+
+		err = downloadArtifact(ctx, opts, a, dir)
+		if err == nil || ctx.Err() != nil || isPermanent(err) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// cleanupFailedDownload lets a platform that kept on-disk state across
+// download attempts (the real API platform's resumable temp file) discard it
+// once downloadWithRetry has given up on the artifact for good, so a
+// permanently failed or retry-exhausted download doesn't leak that file
+// under os.TempDir() forever.
+func cleanupFailedDownload(p Platform, url string) {
+	if cp, ok := p.(ResumeCleanupPlatform); ok {
+		cp.CleanupFailedDownload(url)
+	}
+}
+
+// ResumeCleanupPlatform is implemented by platforms whose Download (or
+// DownloadWithChecksum) keeps a partial file around between retry attempts
+// to support resuming. It's satisfied by the real API-backed platform; test
+// doubles that don't keep any such state can leave it unimplemented.
+type ResumeCleanupPlatform interface {
+	CleanupFailedDownload(url string)
+}
+
+// permanentError wraps a download failure that retrying can't fix: a 4xx
+// response or a checksum mismatch will come back identical on the next
+// attempt, so downloadWithRetry treats it as terminal instead of burning the
+// rest of opts.MaxRetries on it.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func isPermanent(err error) bool {
+	var pe *permanentError
+	return errors.As(err, &pe)
+}
+
+// sleepWithBackoff waits for an exponentially growing, jittered delay before
+// the next retry attempt, returning early with ctx's error if it's cancelled
+// first.
+func sleepWithBackoff(ctx context.Context, attempt int) error {
+	base := 250 * time.Millisecond * time.Duration(1<<uint(attempt-1))
+	jitter := time.Duration(rand.Int63n(int64(base)))
+
+	select {
+	case <-time.After(base + jitter):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// downloadArtifact verifies the artifact's SHA-256 digest while downloading
+// it whenever the platform and the artifact metadata both support it, and
+// falls back to a plain download otherwise.
+func downloadArtifact(ctx context.Context, opts *DownloadOptions, a shared.Artifact, dir string) error {
+	if !opts.SkipChecksum && a.SHA256 != "" {
+		if cp, ok := opts.Platform.(ChecksumPlatform); ok {
+			return cp.DownloadWithChecksum(ctx, a.DownloadURL, dir, a.SHA256)
+		}
+	}
+	return opts.Platform.Download(ctx, a.DownloadURL, dir)
+}
+
+func filterExpired(artifacts []shared.Artifact) []shared.Artifact {
+	var filtered []shared.Artifact
+	for _, a := range artifacts {
+		if !a.Expired {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered
+}
+
+func filterArtifacts(artifacts []shared.Artifact, names, patterns []string, doPrompt bool, p prompter.Prompter) ([]shared.Artifact, error) {
+	if doPrompt {
+		available := uniqueArtifactNames(artifacts)
+		selected, err := p.MultiSelect("Select artifacts to download:", nil, available)
+		if err != nil {
+			return nil, err
+		}
+		names = make([]string, len(selected))
+		for i, idx := range selected {
+			names[i] = available[idx]
+		}
+	}
+
+	if len(names) == 0 && len(patterns) == 0 {
+		return artifacts, nil
+	}
+
+	var filtered []shared.Artifact
+	for _, a := range artifacts {
+		if matchesAny(a.Name, names, patterns) {
+			filtered = append(filtered, a)
+		}
+	}
+	return filtered, nil
+}
+
+func matchesAny(name string, names, patterns []string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func uniqueArtifactNames(artifacts []shared.Artifact) []string {
+	seen := map[string]bool{}
+	var names []string
+	for _, a := range artifacts {
+		if !seen[a.Name] {
+			seen[a.Name] = true
+			names = append(names, a.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func dedupeByName(artifacts []shared.Artifact) []shared.Artifact {
+	seen := map[string]bool{}
+	var deduped []shared.Artifact
+	for _, a := range artifacts {
+		if !seen[a.Name] {
+			seen[a.Name] = true
+			deduped = append(deduped, a)
+		}
+	}
+	return deduped
+}
+
+// moveTree relocates every file under src to the identical relative path
+// under dst, creating dst and any needed subdirectories as it goes. It's
+// used to promote a freshly-downloaded artifact out of its staging
+// directory into its final destination only once the download is known to
+// have succeeded.
+func moveTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+
+		if err := os.Rename(path, target); err == nil {
+			return nil
+		}
+		// os.Rename can't cross a filesystem boundary (the staging
+		// directory lives under os.TempDir(), which may not share a mount
+		// with the destination), so fall back to copying the bytes over.
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
+
+type apiPlatform struct {
+	client *http.Client
+	repo   ghrepo.Interface
+}
+
+func (p *apiPlatform) List(ctx context.Context, runID string) ([]shared.Artifact, error) {
+	return shared.ListArtifacts(ctx, p.client, p.repo, runID)
+}
+
+func (p *apiPlatform) Download(ctx context.Context, url string, dir string) error {
+	tmpPath, err := p.fetch(ctx, url, "")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	return shared.ExtractZip(tmpPath, dir)
+}
+
+func (p *apiPlatform) DownloadWithChecksum(ctx context.Context, url string, dir string, expectedSHA256 string) error {
+	tmpPath, err := p.fetch(ctx, url, expectedSHA256)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	return shared.ExtractZip(tmpPath, dir)
+}
+
+// CleanupFailedDownload removes the partial file fetch keeps around under
+// os.TempDir() to resume url's download, once downloadWithRetry has given up
+// on it for good.
+func (p *apiPlatform) CleanupFailedDownload(url string) {
+	_ = os.Remove(filepath.Join(os.TempDir(), partialFileName(url)))
+	_ = os.Remove(filepath.Join(os.TempDir(), partialValidatorFileName(url)))
+}
+
+// fetch downloads url to a temp file keyed off of url itself, so that a
+// retried call after a truncated body finds its own partial file and resumes
+// with a Range request instead of restarting a multi-GB artifact from zero.
+// When expectedSHA256 is non-empty the whole file is hashed once the download
+// completes (resumed bytes included) and compared before it's returned.
+func (p *apiPlatform) fetch(ctx context.Context, url string, expectedSHA256 string) (string, error) {
+	tmpPath := filepath.Join(os.TempDir(), partialFileName(url))
+	validatorPath := filepath.Join(os.TempDir(), partialValidatorFileName(url))
+
+	offset, err := partialSize(tmpPath)
+	if err != nil {
+		return "", err
+	}
+
+	// A partial file with no stored validator either came from a response
+	// that carried no ETag or Last-Modified, or wasn't written by this
+	// function at all; we have no way to tell it's still the same resource,
+	// so don't risk resuming onto it.
+	var validator string
+	if offset > 0 {
+		validator, err = readValidator(validatorPath)
+		if err != nil {
+			return "", err
+		}
+		if validator == "" {
+			offset = 0
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", validator)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	resuming := offset > 0 && resp.StatusCode == http.StatusPartialContent
+	flags := os.O_CREATE | os.O_WRONLY
+	if resuming {
+		flags |= os.O_APPEND
+	} else {
+		flags |= os.O_TRUNC
+		offset = 0
+		if resp.StatusCode != http.StatusOK {
+			err := fmt.Errorf("unexpected http status code: %d", resp.StatusCode)
+			if resp.StatusCode < http.StatusInternalServerError {
+				// A 4xx means the request itself is bad (missing artifact,
+				// expired URL, bad auth); retrying without changing
+				// anything would just get the same response back.
+				err = &permanentError{err}
+			}
+			return "", err
+		}
+		// The resource might have changed since any validator we stored
+		// earlier (or this might be the first attempt); record whatever
+		// validator this response carries so a later resume can check it
+		// with If-Range before trusting the bytes already on disk.
+		if err := writeValidator(validatorPath, responseValidator(resp)); err != nil {
+			return "", err
+		}
+	}
+
+	f, err := os.OpenFile(tmpPath, flags, 0600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, ctxReader{ctx, resp.Body}); err != nil {
+		// Leave the partial file in place so the next retry can resume from
+		// where this attempt left off.
+		return "", err
+	}
+
+	if expectedSHA256 != "" {
+		got, err := sha256File(tmpPath)
+		if err != nil {
+			return "", err
+		}
+		if got != expectedSHA256 {
+			os.Remove(tmpPath)
+			// The bytes on disk have already been hashed and proven bad;
+			// retrying would just re-download and re-fail the same way.
+			return "", &permanentError{fmt.Errorf("checksum mismatch: expected %s got %s", expectedSHA256, got)}
+		}
+	}
+
+	return tmpPath, nil
+}
+
+// responseValidator returns the ETag or, failing that, Last-Modified header
+// from resp, whichever a later If-Range request can use to confirm the
+// resource hasn't changed before resuming from it.
+func responseValidator(resp *http.Response) string {
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		return etag
+	}
+	return resp.Header.Get("Last-Modified")
+}
+
+func readValidator(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func writeValidator(path string, validator string) error {
+	if validator == "" {
+		// Nothing to check a future resume against, so don't leave a stale
+		// (empty) validator file around claiming otherwise.
+		_ = os.Remove(path)
+		return nil
+	}
+	return os.WriteFile(path, []byte(validator), 0600)
+}
+
+func partialFileName(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return "gh-artifact-" + hex.EncodeToString(sum[:]) + ".zip"
+}
+
+// partialValidatorFileName names the sidecar file that stores the ETag or
+// Last-Modified value fetch saw the last time it wrote tmpPath from a fresh
+// (non-resumed) response, so a later resume attempt can send it back as
+// If-Range instead of trusting the partial file blindly.
+func partialValidatorFileName(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return "gh-artifact-" + hex.EncodeToString(sum[:]) + ".etag"
+}
+
+func partialSize(path string) (int64, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// ctxReader aborts a Read as soon as ctx is done, so a cancelled context
+// interrupts an in-flight zip stream instead of letting it run to completion.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr ctxReader) Read(p []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
@@ -2,13 +2,20 @@ package download
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/cli/cli/v2/internal/ghrepo"
 	"github.com/cli/cli/v2/internal/prompter"
@@ -19,6 +26,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"gocloud.dev/blob"
+	_ "gocloud.dev/blob/memblob"
 )
 
 func Test_NewCmdDownload(t *testing.T) {
@@ -38,6 +47,8 @@ func Test_NewCmdDownload(t *testing.T) {
 				DoPrompt:       true,
 				Names:          []string(nil),
 				DestinationDir: ".",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     defaultMaxRetries,
 			},
 		},
 		{
@@ -49,6 +60,8 @@ func Test_NewCmdDownload(t *testing.T) {
 				DoPrompt:       false,
 				Names:          []string(nil),
 				DestinationDir: ".",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     defaultMaxRetries,
 			},
 		},
 		{
@@ -60,6 +73,8 @@ func Test_NewCmdDownload(t *testing.T) {
 				DoPrompt:       false,
 				Names:          []string(nil),
 				DestinationDir: "tmp/dest",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     defaultMaxRetries,
 			},
 		},
 		{
@@ -71,6 +86,8 @@ func Test_NewCmdDownload(t *testing.T) {
 				DoPrompt:       false,
 				Names:          []string{"one", "two"},
 				DestinationDir: ".",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     defaultMaxRetries,
 			},
 		},
 		{
@@ -82,6 +99,8 @@ func Test_NewCmdDownload(t *testing.T) {
 				DoPrompt:       false,
 				FilePatterns:   []string{"o*e", "tw*"},
 				DestinationDir: ".",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     defaultMaxRetries,
 			},
 		},
 		{
@@ -94,6 +113,84 @@ func Test_NewCmdDownload(t *testing.T) {
 				Names:          []string{"three", "four"},
 				FilePatterns:   []string{"o*e", "tw*"},
 				DestinationDir: ".",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     defaultMaxRetries,
+			},
+		},
+		{
+			name:  "checksum verification on by default",
+			args:  "2345",
+			isTTY: true,
+			want: DownloadOptions{
+				RunID:          "2345",
+				Names:          []string(nil),
+				DestinationDir: ".",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     defaultMaxRetries,
+				SkipChecksum:   false,
+			},
+		},
+		{
+			name:  "--no-verify skips checksum verification",
+			args:  "2345 --no-verify",
+			isTTY: true,
+			want: DownloadOptions{
+				RunID:          "2345",
+				Names:          []string(nil),
+				DestinationDir: ".",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     defaultMaxRetries,
+				SkipChecksum:   true,
+			},
+		},
+		{
+			name:  "--verify=false skips checksum verification",
+			args:  "2345 --verify=false",
+			isTTY: true,
+			want: DownloadOptions{
+				RunID:          "2345",
+				Names:          []string(nil),
+				DestinationDir: ".",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     defaultMaxRetries,
+				SkipChecksum:   true,
+			},
+		},
+		{
+			name:  "--no-verify wins over an explicit --verify",
+			args:  "2345 --verify --no-verify",
+			isTTY: true,
+			want: DownloadOptions{
+				RunID:          "2345",
+				Names:          []string(nil),
+				DestinationDir: ".",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     defaultMaxRetries,
+				SkipChecksum:   true,
+			},
+		},
+		{
+			name:  "--concurrency overrides the default worker count",
+			args:  "2345 --concurrency 10",
+			isTTY: true,
+			want: DownloadOptions{
+				RunID:          "2345",
+				Names:          []string(nil),
+				DestinationDir: ".",
+				Concurrency:    10,
+				MaxRetries:     defaultMaxRetries,
+			},
+		},
+		{
+			name:  "--max-retries overrides the default retry count",
+			args:  "2345 --max-retries 7",
+			isTTY: true,
+			want: DownloadOptions{
+				RunID:          "2345",
+				Names:          []string(nil),
+				DestinationDir: ".",
+				Concurrency:    defaultConcurrency,
+				MaxRetries:     7,
 			},
 		},
 	}
@@ -142,6 +239,9 @@ func Test_NewCmdDownload(t *testing.T) {
 			assert.Equal(t, tt.want.FilePatterns, opts.FilePatterns)
 			assert.Equal(t, tt.want.DestinationDir, opts.DestinationDir)
 			assert.Equal(t, tt.want.DoPrompt, opts.DoPrompt)
+			assert.Equal(t, tt.want.SkipChecksum, opts.SkipChecksum)
+			assert.Equal(t, tt.want.Concurrency, opts.Concurrency)
+			assert.Equal(t, tt.want.MaxRetries, opts.MaxRetries)
 		})
 	}
 }
@@ -155,7 +255,7 @@ type fakePlatform struct {
 	runArtifacts map[string][]testArtifact
 }
 
-func (f *fakePlatform) List(runID string) ([]shared.Artifact, error) {
+func (f *fakePlatform) List(ctx context.Context, runID string) ([]shared.Artifact, error) {
 	var runIds []string
 	if runID != "" {
 		runIds = []string{runID}
@@ -175,7 +275,7 @@ func (f *fakePlatform) List(runID string) ([]shared.Artifact, error) {
 	return artifacts, nil
 }
 
-func (f *fakePlatform) Download(url string, dir string) error {
+func (f *fakePlatform) Download(ctx context.Context, url string, dir string) error {
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
@@ -449,6 +549,207 @@ func Test_runDownloadFake(t *testing.T) {
 	}
 }
 
+// cancelAwarePlatform blocks its Download call on ctx.Done() so a test can
+// cancel mid-download and assert the partially-written directory is cleaned
+// up rather than left behind for a later run to mistake as complete.
+type cancelAwarePlatform struct {
+	artifacts       []shared.Artifact
+	startedDownload chan struct{}
+}
+
+func (p *cancelAwarePlatform) List(ctx context.Context, runID string) ([]shared.Artifact, error) {
+	return p.artifacts, nil
+}
+
+func (p *cancelAwarePlatform) Download(ctx context.Context, url string, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "partial"), []byte{}, 0600); err != nil {
+		return err
+	}
+	close(p.startedDownload)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func Test_runDownload_cancel(t *testing.T) {
+	destDir := t.TempDir()
+	platform := &cancelAwarePlatform{
+		artifacts: []shared.Artifact{
+			{Name: "artifact-1", DownloadURL: "http://download.com/artifact1.zip"},
+			{Name: "artifact-2", DownloadURL: "http://download.com/artifact2.zip"},
+		},
+		startedDownload: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ios, _, _, _ := iostreams.Test()
+	opts := &DownloadOptions{
+		Context:        ctx,
+		RunID:          "2345",
+		DestinationDir: destDir,
+		IO:             ios,
+		Platform:       platform,
+		Concurrency:    1,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- runDownload(opts)
+	}()
+
+	<-platform.startedDownload
+	cancel()
+
+	err := <-errCh
+	require.ErrorIs(t, err, context.Canceled)
+
+	require.NoDirExists(t, filepath.Join(destDir, "artifact-1"))
+}
+
+// Test_runDownload_cancel_singleArtifactPreservesDestDir guards against a
+// single artifact's cancelled download wiping out --dir wholesale: with only
+// one artifact the destination directory is used directly rather than a
+// per-artifact subdirectory, so a naive cleanup that os.RemoveAll()s it would
+// take any pre-existing, unrelated files in it down too.
+func Test_runDownload_cancel_singleArtifactPreservesDestDir(t *testing.T) {
+	destDir := t.TempDir()
+	sentinel := filepath.Join(destDir, "pre-existing.txt")
+	require.NoError(t, os.WriteFile(sentinel, []byte("keep me"), 0600))
+
+	platform := &cancelAwarePlatform{
+		artifacts: []shared.Artifact{
+			{Name: "artifact-1", DownloadURL: "http://download.com/artifact1.zip"},
+		},
+		startedDownload: make(chan struct{}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ios, _, _, _ := iostreams.Test()
+	opts := &DownloadOptions{
+		Context:        ctx,
+		RunID:          "2345",
+		DestinationDir: destDir,
+		IO:             ios,
+		Platform:       platform,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- runDownload(opts)
+	}()
+
+	<-platform.startedDownload
+	cancel()
+
+	err := <-errCh
+	require.ErrorIs(t, err, context.Canceled)
+
+	require.FileExists(t, sentinel)
+	require.NoFileExists(t, filepath.Join(destDir, "partial"))
+}
+
+// checksumFakePlatform downloads a single artifact whose expected SHA-256
+// it compares against the checksum passed to DownloadWithChecksum, mirroring
+// how the real API platform checks the zip's digest before extracting it.
+type checksumFakePlatform struct {
+	content []byte
+	sha256  string
+}
+
+func (p *checksumFakePlatform) List(ctx context.Context, runID string) ([]shared.Artifact, error) {
+	return []shared.Artifact{
+		{Name: "artifact-1", DownloadURL: "http://download.com/artifact1.zip", SHA256: p.sha256},
+	}, nil
+}
+
+func (p *checksumFakePlatform) Download(ctx context.Context, url string, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "content"), p.content, 0600)
+}
+
+func (p *checksumFakePlatform) DownloadWithChecksum(ctx context.Context, url string, dir string, expectedSHA256 string) error {
+	sum := sha256.Sum256(p.content)
+	got := hex.EncodeToString(sum[:])
+	if got != expectedSHA256 {
+		return fmt.Errorf("checksum mismatch: expected %s got %s", expectedSHA256, got)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "content"), p.content, 0600)
+}
+
+func Test_runDownload_checksum(t *testing.T) {
+	content := []byte("artifact contents")
+	sum := sha256.Sum256(content)
+	validSHA256 := hex.EncodeToString(sum[:])
+	wrongSHA256 := strings.Repeat("0", 64)
+
+	tests := []struct {
+		name    string
+		sha256  string
+		wantErr string
+	}{
+		{
+			name:   "matching checksum",
+			sha256: validSHA256,
+		},
+		{
+			name:    "mismatched checksum",
+			sha256:  wrongSHA256,
+			wantErr: fmt.Sprintf("error downloading artifact-1: checksum mismatch: expected %s got %s", wrongSHA256, validSHA256),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destDir := t.TempDir()
+			ios, _, _, _ := iostreams.Test()
+			opts := &DownloadOptions{
+				RunID:          "2345",
+				DestinationDir: destDir,
+				IO:             ios,
+				Platform:       &checksumFakePlatform{content: content, sha256: tt.sha256},
+			}
+
+			err := runDownload(opts)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				require.FileExists(t, filepath.Join(destDir, "content"))
+			}
+		})
+	}
+}
+
+func Test_runDownload_checksum_skipped(t *testing.T) {
+	destDir := t.TempDir()
+	ios, _, _, _ := iostreams.Test()
+	opts := &DownloadOptions{
+		RunID:          "2345",
+		DestinationDir: destDir,
+		IO:             ios,
+		SkipChecksum:   true,
+		Platform: &checksumFakePlatform{
+			content: []byte("artifact contents"),
+			sha256:  strings.Repeat("0", 64), // would fail verification if checked
+		},
+	}
+
+	err := runDownload(opts)
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(destDir, "content"))
+}
+
 func Test_runDownload(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -482,8 +783,8 @@ func Test_runDownload(t *testing.T) {
 						Expired:     false,
 					},
 				}, nil)
-				p.On("Download", "http://download.com/artifact1.zip", filepath.FromSlash("tmp/artifact-1")).Return(nil)
-				p.On("Download", "http://download.com/artifact2.zip", filepath.FromSlash("tmp/artifact-2")).Return(nil)
+				p.On("Download", "http://download.com/artifact1.zip", mock.AnythingOfType("string")).Return(nil)
+				p.On("Download", "http://download.com/artifact2.zip", mock.AnythingOfType("string")).Return(nil)
 			},
 		},
 		{
@@ -586,7 +887,7 @@ func Test_runDownload(t *testing.T) {
 						Expired:     false,
 					},
 				}, nil)
-				p.On("Download", "http://download.com/artifact2.zip", ".").Return(nil)
+				p.On("Download", "http://download.com/artifact2.zip", mock.AnythingOfType("string")).Return(nil)
 			},
 			promptStubs: func(pm *prompter.MockPrompter) {
 				pm.RegisterMultiSelect("Select artifacts to download:", nil, []string{"artifact-1", "artifact-2"},
@@ -638,12 +939,426 @@ func newMockPlatform(t *testing.T, config func(*mockPlatform)) *mockPlatform {
 	return m
 }
 
-func (p *mockPlatform) List(runID string) ([]shared.Artifact, error) {
+func (p *mockPlatform) List(ctx context.Context, runID string) ([]shared.Artifact, error) {
 	args := p.Called(runID)
 	return args.Get(0).([]shared.Artifact), args.Error(1)
 }
 
-func (p *mockPlatform) Download(url string, dir string) error {
+func (p *mockPlatform) Download(ctx context.Context, url string, dir string) error {
 	args := p.Called(url, dir)
 	return args.Error(0)
 }
+
+func Test_runDownload_concurrency(t *testing.T) {
+	var artifacts []shared.Artifact
+	for i := 0; i < 3; i++ {
+		artifacts = append(artifacts, shared.Artifact{
+			Name:        fmt.Sprintf("artifact-%d", i),
+			DownloadURL: fmt.Sprintf("http://download.com/artifact%d.zip", i),
+		})
+	}
+
+	p := newMockPlatform(t, func(p *mockPlatform) {
+		p.On("List", "2345").Return(artifacts, nil)
+		for _, a := range artifacts {
+			p.On("Download", a.DownloadURL, mock.AnythingOfType("string")).
+				Run(func(mock.Arguments) { time.Sleep(100 * time.Millisecond) }).
+				Return(nil)
+		}
+	})
+
+	ios, _, _, _ := iostreams.Test()
+	opts := &DownloadOptions{
+		RunID:          "2345",
+		DestinationDir: ".",
+		Concurrency:    3,
+		IO:             ios,
+		Platform:       p,
+		Prompter:       prompter.NewMockPrompter(t),
+	}
+
+	start := time.Now()
+	err := runDownload(opts)
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	// Three 100ms downloads run concurrently should finish well under the
+	// 300ms a sequential loop would need.
+	assert.Less(t, elapsed, 250*time.Millisecond)
+}
+
+// cancelCountingPlatform fails its first Download call and has every other
+// concurrent call block on ctx.Done(), so a test can assert that a single
+// failure cancels the rest of the worker pool instead of letting it run to
+// completion.
+type cancelCountingPlatform struct {
+	artifacts []shared.Artifact
+
+	mu        sync.Mutex
+	started   int
+	cancelled int
+}
+
+func (p *cancelCountingPlatform) List(ctx context.Context, runID string) ([]shared.Artifact, error) {
+	return p.artifacts, nil
+}
+
+func (p *cancelCountingPlatform) Download(ctx context.Context, url string, dir string) error {
+	p.mu.Lock()
+	p.started++
+	isFirst := p.started == 1
+	p.mu.Unlock()
+
+	if isFirst {
+		return errors.New("boom")
+	}
+
+	select {
+	case <-ctx.Done():
+		p.mu.Lock()
+		p.cancelled++
+		p.mu.Unlock()
+		return ctx.Err()
+	case <-time.After(time.Second):
+		return nil
+	}
+}
+
+func Test_runDownload_concurrency_cancelOnFailure(t *testing.T) {
+	var artifacts []shared.Artifact
+	for i := 0; i < 3; i++ {
+		artifacts = append(artifacts, shared.Artifact{
+			Name:        fmt.Sprintf("artifact-%d", i),
+			DownloadURL: fmt.Sprintf("http://download.com/artifact%d.zip", i),
+		})
+	}
+
+	platform := &cancelCountingPlatform{artifacts: artifacts}
+	ios, _, _, _ := iostreams.Test()
+	opts := &DownloadOptions{
+		RunID:          "2345",
+		DestinationDir: t.TempDir(),
+		Concurrency:    3,
+		IO:             ios,
+		Platform:       platform,
+	}
+
+	err := runDownload(opts)
+	require.ErrorContains(t, err, "boom")
+
+	platform.mu.Lock()
+	defer platform.mu.Unlock()
+	assert.Equal(t, 2, platform.cancelled)
+}
+
+func Test_runDownload_cloudDestination(t *testing.T) {
+	ctx := context.Background()
+	bucketURL := "mem://Test_runDownload_cloudDestination"
+
+	platform := &fakePlatform{
+		runArtifacts: map[string][]testArtifact{
+			"2345": {
+				{
+					artifact: shared.Artifact{Name: "artifact-1", DownloadURL: "http://download.com/artifact1.zip"},
+					files:    []string{"one.txt"},
+				},
+				{
+					artifact: shared.Artifact{Name: "artifact-2", DownloadURL: "http://download.com/artifact2.zip"},
+					files:    []string{"two.txt"},
+				},
+			},
+		},
+	}
+
+	ios, _, _, _ := iostreams.Test()
+	opts := &DownloadOptions{
+		RunID:          "2345",
+		DestinationDir: bucketURL,
+		IO:             ios,
+		Platform:       platform,
+	}
+
+	err := runDownload(opts)
+	require.NoError(t, err)
+
+	bucket, err := blob.OpenBucket(ctx, bucketURL)
+	require.NoError(t, err)
+	defer bucket.Close()
+
+	for _, key := range []string{"artifact-1/one.txt", "artifact-2/two.txt"} {
+		exists, err := bucket.Exists(ctx, key)
+		require.NoError(t, err)
+		assert.True(t, exists, "expected object %s to exist", key)
+	}
+}
+
+// flakyFakePlatform fails its first failUntilAttempt Download calls with a
+// simulated truncated body before succeeding, so a test can assert both the
+// retry count and that the final content on disk is intact.
+type flakyFakePlatform struct {
+	content          []byte
+	failUntilAttempt int
+
+	mu       sync.Mutex
+	attempts int
+}
+
+func (p *flakyFakePlatform) List(ctx context.Context, runID string) ([]shared.Artifact, error) {
+	return []shared.Artifact{
+		{Name: "artifact-1", DownloadURL: "http://download.com/artifact1.zip"},
+	}, nil
+}
+
+func (p *flakyFakePlatform) Download(ctx context.Context, url string, dir string) error {
+	p.mu.Lock()
+	p.attempts++
+	attempt := p.attempts
+	p.mu.Unlock()
+
+	if attempt <= p.failUntilAttempt {
+		return io.ErrUnexpectedEOF
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "content"), p.content, 0600)
+}
+
+func Test_runDownload_retry(t *testing.T) {
+	content := []byte("artifact contents")
+
+	tests := []struct {
+		name             string
+		failUntilAttempt int
+		maxRetries       int
+		wantAttempts     int
+		wantErr          bool
+	}{
+		{
+			name:             "succeeds after transient failures",
+			failUntilAttempt: 2,
+			maxRetries:       3,
+			wantAttempts:     3,
+		},
+		{
+			name:             "exhausts retries",
+			failUntilAttempt: 5,
+			maxRetries:       2,
+			wantAttempts:     3,
+			wantErr:          true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destDir := t.TempDir()
+			platform := &flakyFakePlatform{content: content, failUntilAttempt: tt.failUntilAttempt}
+
+			ios, _, _, _ := iostreams.Test()
+			opts := &DownloadOptions{
+				RunID:          "2345",
+				DestinationDir: destDir,
+				IO:             ios,
+				Platform:       platform,
+				MaxRetries:     tt.maxRetries,
+			}
+
+			err := runDownload(opts)
+
+			platform.mu.Lock()
+			attempts := platform.attempts
+			platform.mu.Unlock()
+			assert.Equal(t, tt.wantAttempts, attempts)
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+				return
+			}
+
+			require.NoError(t, err)
+			got, err := os.ReadFile(filepath.Join(destDir, "content"))
+			require.NoError(t, err)
+			assert.Equal(t, content, got)
+		})
+	}
+}
+
+// countingErrPlatform always fails Download with the given error, counting
+// how many times it was called so a test can assert retries stopped early.
+type countingErrPlatform struct {
+	err      error
+	attempts int
+}
+
+func (p *countingErrPlatform) List(ctx context.Context, runID string) ([]shared.Artifact, error) {
+	return []shared.Artifact{{Name: "artifact-1", DownloadURL: "http://download.com/artifact1.zip"}}, nil
+}
+
+func (p *countingErrPlatform) Download(ctx context.Context, url string, dir string) error {
+	p.attempts++
+	return p.err
+}
+
+func Test_downloadWithRetry_permanentErrorNotRetried(t *testing.T) {
+	platform := &countingErrPlatform{err: &permanentError{errors.New("checksum mismatch: expected aaa got bbb")}}
+	opts := &DownloadOptions{Platform: platform, MaxRetries: 5}
+	artifact := shared.Artifact{Name: "artifact-1", DownloadURL: "http://download.com/artifact1.zip"}
+
+	err := downloadWithRetry(context.Background(), opts, artifact, t.TempDir())
+
+	require.Error(t, err)
+	assert.Equal(t, 1, platform.attempts)
+}
+
+// cleanupCountingPlatform always fails Download with the given error and
+// counts calls to CleanupFailedDownload, so a test can assert a resumable
+// platform's partial file is discarded once downloadWithRetry gives up.
+type cleanupCountingPlatform struct {
+	err           error
+	cleanupCalls  int
+	cleanedUpURLs []string
+}
+
+func (p *cleanupCountingPlatform) List(ctx context.Context, runID string) ([]shared.Artifact, error) {
+	return []shared.Artifact{{Name: "artifact-1", DownloadURL: "http://download.com/artifact1.zip"}}, nil
+}
+
+func (p *cleanupCountingPlatform) Download(ctx context.Context, url string, dir string) error {
+	return p.err
+}
+
+func (p *cleanupCountingPlatform) CleanupFailedDownload(url string) {
+	p.cleanupCalls++
+	p.cleanedUpURLs = append(p.cleanedUpURLs, url)
+}
+
+func Test_downloadWithRetry_cleansUpPartialFileOnGiveUp(t *testing.T) {
+	platform := &cleanupCountingPlatform{err: &permanentError{errors.New("checksum mismatch: expected aaa got bbb")}}
+	opts := &DownloadOptions{Platform: platform, MaxRetries: 5}
+	artifact := shared.Artifact{Name: "artifact-1", DownloadURL: "http://download.com/artifact1.zip"}
+
+	err := downloadWithRetry(context.Background(), opts, artifact, t.TempDir())
+
+	require.Error(t, err)
+	assert.Equal(t, 1, platform.cleanupCalls)
+	assert.Equal(t, []string{artifact.DownloadURL}, platform.cleanedUpURLs)
+}
+
+func Test_downloadWithRetry_noCleanupOnSuccess(t *testing.T) {
+	platform := &cleanupCountingPlatform{err: nil}
+	opts := &DownloadOptions{Platform: platform, MaxRetries: 5}
+	artifact := shared.Artifact{Name: "artifact-1", DownloadURL: "http://download.com/artifact1.zip"}
+
+	err := downloadWithRetry(context.Background(), opts, artifact, t.TempDir())
+
+	require.NoError(t, err)
+	assert.Equal(t, 0, platform.cleanupCalls)
+}
+
+// Test_apiPlatform_fetch_resumesTruncatedDownload drives apiPlatform.fetch
+// against a server that truncates its first response mid-body, then
+// confirms a second call resumes via a Range request rather than
+// restarting, and that the bytes on disk end up matching the full artifact.
+func Test_apiPlatform_fetch_resumesTruncatedDownload(t *testing.T) {
+	full := bytes.Repeat([]byte("artifact-bytes-"), 1000)
+	truncateAt := len(full) / 2
+	requests := 0
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/artifact.zip", func(w http.ResponseWriter, r *http.Request) {
+		requests++
+
+		rangeHeader := r.Header.Get("Range")
+		if rangeHeader == "" {
+			// Declare the full length but only write half of it and return,
+			// which makes the client's io.Copy fail with an unexpected EOF
+			// partway through, as if the connection had dropped.
+			w.Header().Set("ETag", `"full-artifact"`)
+			w.Header().Set("Content-Length", fmt.Sprintf("%d", len(full)))
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write(full[:truncateAt])
+			return
+		}
+
+		require.Equal(t, `"full-artifact"`, r.Header.Get("If-Range"))
+
+		var offset int
+		_, err := fmt.Sscanf(rangeHeader, "bytes=%d-", &offset)
+		require.NoError(t, err)
+		require.Equal(t, truncateAt, offset)
+
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(full)-1, len(full)))
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write(full[offset:])
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	platform := &apiPlatform{client: srv.Client()}
+	url := srv.URL + "/artifact.zip"
+
+	tmpPath := filepath.Join(os.TempDir(), partialFileName(url))
+	defer os.Remove(tmpPath)
+	defer os.Remove(filepath.Join(os.TempDir(), partialValidatorFileName(url)))
+
+	_, err := platform.fetch(context.Background(), url, "")
+	require.Error(t, err)
+
+	gotOffset, err := partialSize(tmpPath)
+	require.NoError(t, err)
+	assert.Equal(t, int64(truncateAt), gotOffset)
+
+	resumedPath, err := platform.fetch(context.Background(), url, "")
+	require.NoError(t, err)
+	assert.Equal(t, tmpPath, resumedPath)
+
+	gotBytes, err := os.ReadFile(resumedPath)
+	require.NoError(t, err)
+	assert.Equal(t, full, gotBytes)
+
+	assert.Equal(t, 2, requests)
+}
+
+// Test_apiPlatform_fetch_discardsStalePartialWithoutValidator simulates a
+// partial file left on disk by something other than a tracked fetch attempt
+// (e.g. a process killed before CleanupFailedDownload could run, on an
+// earlier, unrelated, shorter artifact sharing the same temp path by
+// coincidence of URL hashing in a test). With no stored validator to send as
+// If-Range, fetch must not trust those bytes and resume onto them; it should
+// restart the download from scratch instead.
+func Test_apiPlatform_fetch_discardsStalePartialWithoutValidator(t *testing.T) {
+	full := bytes.Repeat([]byte("artifact-bytes-"), 1000)
+	sawRange := false
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/artifact.zip", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			sawRange = true
+		}
+		w.Header().Set("ETag", `"full-artifact"`)
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(full)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	platform := &apiPlatform{client: srv.Client()}
+	url := srv.URL + "/artifact.zip"
+
+	tmpPath := filepath.Join(os.TempDir(), partialFileName(url))
+	defer os.Remove(tmpPath)
+	defer os.Remove(filepath.Join(os.TempDir(), partialValidatorFileName(url)))
+
+	// Plant a stale, shorter "partial" file with no validator sidecar, as if
+	// left over from an untracked prior run.
+	require.NoError(t, os.WriteFile(tmpPath, []byte("stale-unrelated-bytes"), 0600))
+
+	gotPath, err := platform.fetch(context.Background(), url, "")
+	require.NoError(t, err)
+
+	assert.False(t, sawRange, "fetch should not have sent a Range request against an unvalidated partial file")
+
+	gotBytes, err := os.ReadFile(gotPath)
+	require.NoError(t, err)
+	assert.Equal(t, full, gotBytes)
+}
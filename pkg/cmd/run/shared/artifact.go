@@ -0,0 +1,217 @@
+package shared
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/cli/cli/v2/internal/ghrepo"
+)
+
+// Artifact is a single build artifact attached to a workflow run.
+type Artifact struct {
+	Name        string
+	DownloadURL string
+	Expired     bool
+
+	// SHA256 is the artifact's expected checksum, when known. It's read
+	// from the artifact's own API metadata if the API reports one, or else
+	// from a companion "<name>.sha256" sidecar artifact uploaded alongside
+	// it by the workflow. It's empty when neither source is available, in
+	// which case checksum verification is skipped for that artifact.
+	SHA256 string
+}
+
+type apiArtifact struct {
+	Name                      string `json:"name"`
+	ArchiveDownloadURL        string `json:"archive_download_url"`
+	Expired                   bool   `json:"expired"`
+	WorkflowRunArtifactSHA256 string `json:"workflow_run_artifact_sha256"`
+}
+
+type artifactsPayload struct {
+	Artifacts []apiArtifact `json:"artifacts"`
+}
+
+// ListArtifacts fetches the artifacts for runID (or every artifact in the
+// repo when runID is empty), resolving each one's SHA256 from whichever
+// source is available.
+func ListArtifacts(ctx context.Context, client *http.Client, repo ghrepo.Interface, runID string) ([]Artifact, error) {
+	apiPath := fmt.Sprintf("repos/%s/%s/actions/artifacts", repo.RepoOwner(), repo.RepoName())
+	if runID != "" {
+		apiPath = fmt.Sprintf("repos/%s/%s/actions/runs/%s/artifacts", repo.RepoOwner(), repo.RepoName(), runID)
+	}
+
+	var payload artifactsPayload
+	if err := getJSON(ctx, client, repo, apiPath, &payload); err != nil {
+		return nil, err
+	}
+
+	sidecarURLs := map[string]string{}
+	for _, a := range payload.Artifacts {
+		if name, ok := strings.CutSuffix(a.Name, ".sha256"); ok {
+			sidecarURLs[name] = a.ArchiveDownloadURL
+		}
+	}
+
+	var artifacts []Artifact
+	for _, a := range payload.Artifacts {
+		if strings.HasSuffix(a.Name, ".sha256") {
+			continue
+		}
+
+		sha256 := a.WorkflowRunArtifactSHA256
+		if sha256 == "" {
+			if sidecarURL, ok := sidecarURLs[a.Name]; ok {
+				digest, err := fetchSidecarDigest(ctx, client, sidecarURL)
+				if err != nil {
+					return nil, fmt.Errorf("error reading checksum sidecar for %s: %w", a.Name, err)
+				}
+				sha256 = digest
+			}
+		}
+
+		artifacts = append(artifacts, Artifact{
+			Name:        a.Name,
+			DownloadURL: a.ArchiveDownloadURL,
+			Expired:     a.Expired,
+			SHA256:      sha256,
+		})
+	}
+
+	return artifacts, nil
+}
+
+// fetchSidecarDigest downloads a "<name>.sha256" artifact and returns the
+// digest it contains. Sidecars follow the conventional sha256sum format,
+// "<digest>  <filename>", so only the first field is taken.
+func fetchSidecarDigest(ctx context.Context, client *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected http status code: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(string(body))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("checksum sidecar is empty")
+	}
+	return fields[0], nil
+}
+
+func getJSON(ctx context.Context, client *http.Client, repo ghrepo.Interface, apiPath string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s", apiBaseURL(repo), apiPath), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected http status code: %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// apiBaseURL is a variable so tests can point it at an httptest server
+// instead of the real API.
+var apiBaseURL = func(repo ghrepo.Interface) string {
+	if host := repo.RepoHost(); host != "" && host != "github.com" {
+		return fmt.Sprintf("https://%s/api/v3", host)
+	}
+	return "https://api.github.com"
+}
+
+// ExtractZip extracts the zip archive at zipPath into dir, creating dir (and
+// any parent directories) as needed.
+func ExtractZip(zipPath string, dir string) error {
+	r, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, f := range r.File {
+		path, err := entryPath(dir, f.Name)
+		if err != nil {
+			return err
+		}
+
+		if f.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+
+		if err := extractZipFile(f, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// entryPath joins dir and name the way ExtractZip needs to, but first
+// guards against zip-slip: a malicious archive entry named e.g.
+// "../../../../home/user/.ssh/authorized_keys" would otherwise resolve
+// outside dir and let the archive overwrite arbitrary files the process can
+// write to.
+func entryPath(dir, name string) (string, error) {
+	path := filepath.Join(dir, name)
+	if path != filepath.Clean(dir) && !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+		return "", fmt.Errorf("invalid archive entry path: %q escapes destination directory", name)
+	}
+	return path, nil
+}
+
+func extractZipFile(f *zip.File, path string) error {
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, f.Mode())
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	_, err = io.Copy(dst, src)
+	return err
+}
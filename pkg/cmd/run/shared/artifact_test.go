@@ -0,0 +1,108 @@
+package shared
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cli/cli/v2/internal/ghrepo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ListArtifacts(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/OWNER/REPO/actions/runs/2345/artifacts", func(w http.ResponseWriter, r *http.Request) {
+		// The sidecar's URL points back at this server so ListArtifacts
+		// fetches it from the "/a2.sha256" handler below.
+		sidecarURL := "http://" + r.Host + "/a2.sha256"
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{
+			"artifacts": [
+				{"name": "with-api-checksum", "archive_download_url": "http://download.com/a1.zip", "expired": false, "workflow_run_artifact_sha256": "deadbeef"},
+				{"name": "with-sidecar", "archive_download_url": "http://download.com/a2.zip", "expired": false},
+				{"name": "with-sidecar.sha256", "archive_download_url": %q, "expired": false},
+				{"name": "no-checksum", "archive_download_url": "http://download.com/a3.zip", "expired": true}
+			]
+		}`, sidecarURL)
+	})
+	mux.HandleFunc("/a2.sha256", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("cafef00d  with-sidecar.zip\n"))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	oldBaseURL := apiBaseURL
+	apiBaseURL = func(ghrepo.Interface) string { return srv.URL }
+	t.Cleanup(func() { apiBaseURL = oldBaseURL })
+
+	repo := ghrepo.NewWithHost("OWNER", "REPO", "github.com")
+	artifacts, err := ListArtifacts(context.Background(), srv.Client(), repo, "2345")
+	require.NoError(t, err)
+
+	byName := map[string]Artifact{}
+	for _, a := range artifacts {
+		byName[a.Name] = a
+	}
+
+	require.Contains(t, byName, "with-api-checksum")
+	assert.Equal(t, "deadbeef", byName["with-api-checksum"].SHA256)
+
+	require.Contains(t, byName, "with-sidecar")
+	assert.Equal(t, "cafef00d", byName["with-sidecar"].SHA256)
+
+	require.Contains(t, byName, "no-checksum")
+	assert.Equal(t, "", byName["no-checksum"].SHA256)
+
+	assert.NotContains(t, byName, "with-sidecar.sha256")
+}
+
+func Test_ExtractZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("nested/file.txt")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	zipPath := filepath.Join(t.TempDir(), "artifact.zip")
+	require.NoError(t, os.WriteFile(zipPath, buf.Bytes(), 0600))
+
+	dir := filepath.Join(t.TempDir(), "out")
+	require.NoError(t, ExtractZip(zipPath, dir))
+
+	got, err := os.ReadFile(filepath.Join(dir, "nested", "file.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hello", string(got))
+}
+
+// Test_ExtractZip_rejectsZipSlip guards against a malicious archive entry
+// whose name climbs out of dir via ".." components and would otherwise let
+// ExtractZip overwrite arbitrary files outside the destination.
+func Test_ExtractZip_rejectsZipSlip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("../../../../tmp/gh-zip-slip-pwned")
+	require.NoError(t, err)
+	_, err = w.Write([]byte("pwned"))
+	require.NoError(t, err)
+	require.NoError(t, zw.Close())
+
+	zipPath := filepath.Join(t.TempDir(), "artifact.zip")
+	require.NoError(t, os.WriteFile(zipPath, buf.Bytes(), 0600))
+
+	dir := filepath.Join(t.TempDir(), "out")
+	escapedPath := filepath.Join(dir, "../../../../tmp/gh-zip-slip-pwned")
+
+	err = ExtractZip(zipPath, dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "escapes destination directory")
+	require.NoFileExists(t, escapedPath)
+}